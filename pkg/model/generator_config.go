@@ -14,7 +14,14 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/ghodss/yaml"
 )
@@ -27,6 +34,34 @@ type GeneratorConfig struct {
 	Resources map[string]ResourceGeneratorConfig `json:"resources"`
 	// CRDs to ignore. ACK generator would skip these resources.
 	Ignore IgnoreSpec `json:"ignore"`
+	// Kubernetes contains service-wide defaults for the discovery metadata
+	// emitted for every resource's CRD. Individual resources may override
+	// any of these fields via their own ResourceGeneratorConfig.Kubernetes;
+	// unset fields fall back to these defaults, and Defaults.Kubernetes (if
+	// set) can refine them further before a resource's own Kubernetes is
+	// applied on top. This is most useful for Categories, which is almost
+	// always the same across all of a service's resources (e.g. `[aws,
+	// sns]`).
+	Kubernetes *KubernetesConfig `json:"kubernetes,omitempty"`
+	// Includes is a list of other generator config YAML files, relative to
+	// this config's directory, to merge into this one. Includes are merged
+	// in list order, and a later include overrides a field set by an
+	// earlier one. This lets a large service surface (EC2, RDS, ...) split
+	// its generator.yaml into multiple files that different maintainers can
+	// edit without stepping on each other.
+	Includes []string `json:"includes,omitempty"`
+	// Defaults contains ResourceGeneratorConfig fields -- e.g. Exceptions or
+	// Renames -- that apply to every resource in the service unless that
+	// resource sets the same field itself.
+	Defaults *ResourceGeneratorConfig `json:"defaults,omitempty"`
+
+	// sourceDocuments and resourceOverrides are provenance recorded by
+	// NewGeneratorConfig as it walks the root config, its includes, and any
+	// resource fragments, so that Validate can check for unknown fields and
+	// conflicting overrides without re-reading the filesystem. They are
+	// unexported and therefore never (un)marshalled.
+	sourceDocuments   []sourceDocument
+	resourceOverrides map[string][]resourceOverride
 }
 
 // IgnoreSpec represents instructions to the ACK code generator to
@@ -51,7 +86,17 @@ type ResourceGeneratorConfig struct {
 	// isn't set, then the generator will look for a field called "Name" or
 	// "{Resource}Name" or "{Resource}Id" because, well, because we can never
 	// have nice things.
+	//
+	// NameField is superseded by Identifier, which covers resources whose
+	// identity isn't a single plain name field -- ARNs, composite keys,
+	// externally-adopted resources, etc. If both are set, Identifier wins.
 	NameField *string `json:"name_field,omitempty"`
+	// Identifier describes how the code generator should resolve and manage
+	// this resource's primary identifier. Set this instead of NameField for
+	// resources identified by an ARN, a composite of several fields, or
+	// whose identity needs to support adopting an existing AWS resource
+	// instead of always creating a new one.
+	Identifier *IdentifierConfig `json:"identifier,omitempty"`
 	// UnpackAttributeMapConfig contains instructions for converting a raw
 	// `map[string]*string` into real fields on a CRD's Spec or Status object
 	UnpackAttributesMapConfig *UnpackAttributesMapConfig `json:"unpack_attributes_map,omitempty"`
@@ -73,6 +118,53 @@ type ResourceGeneratorConfig struct {
 	// filter the results of these List operations from within the generated
 	// code in sdk.go's sdkFind().
 	ListOperation *ListOperationConfig `json:"list_operation,omitempty"`
+	// Kubernetes contains the Kubernetes discovery metadata -- categories,
+	// short names, singular/plural forms and scope -- that the generator
+	// should emit for this resource's CRD manifest and +kubebuilder
+	// markers. Any field left unset here falls back to the service-wide
+	// default in GeneratorConfig.Kubernetes, if one is set.
+	Kubernetes *KubernetesConfig `json:"kubernetes,omitempty"`
+	// Fields contains FieldGeneratorConfig instructions, keyed by Spec/Status
+	// field name, for fields of the resource that are *not* part of an
+	// UnpackAttributesMapConfig. This is how a resource declares
+	// `+kubebuilder:validation:*` constraints -- via each field's Validation
+	// -- or marks a field Immutable, for fields that come from the regular
+	// Create/Describe Input and Output shapes rather than an Attributes map.
+	Fields map[string]FieldGeneratorConfig `json:"fields,omitempty"`
+}
+
+// KubernetesConfig contains instructions to the code generator about the
+// Kubernetes API discovery metadata -- the same metadata Kubernetes exposes
+// for a resource via `APIResource` (categories, shortNames, namespaced,
+// kind, singularName) -- that should be attached to a CRD.
+//
+// This lets a generated CRD participate in aggregate `kubectl get`
+// commands, e.g. `kubectl get all` or `kubectl get sub` for an
+// SNSSubscription that declares `ShortNames: [sub]`.
+type KubernetesConfig struct {
+	// Categories is the list of category names, e.g. `all` or `aws`, that
+	// `kubectl get <category>` should match this resource against. Rendered
+	// into the CRD manifest's `spec.names.categories` and the
+	// `+kubebuilder:resource:categories=...` marker.
+	Categories []string `json:"categories,omitempty"`
+	// ShortNames is the list of short aliases, e.g. `sub` for
+	// SNSSubscription, that `kubectl get` accepts in place of the full
+	// resource name. Rendered into `spec.names.shortNames` and the
+	// `+kubebuilder:resource:shortName=...` marker.
+	ShortNames []string `json:"short_names,omitempty"`
+	// Singular overrides the singular form of the resource name used in
+	// CLI messages. Rendered into `spec.names.singular`.
+	Singular string `json:"singular,omitempty"`
+	// Plural overrides the plural form of the resource name used in the
+	// CRD's resource path. Rendered into `spec.names.plural`.
+	Plural string `json:"plural,omitempty"`
+	// ListKind overrides the Kind of the list type for this resource, e.g.
+	// `TopicList`. Rendered into `spec.names.listKind`.
+	ListKind string `json:"list_kind,omitempty"`
+	// Scope overrides the CRD's scope. Valid values are `Namespaced` and
+	// `Cluster`. If unset, the generator defaults to `Namespaced`, which is
+	// the scope of every ACK-managed resource today.
+	Scope string `json:"scope,omitempty"`
 }
 
 // UnpackAttributesMapConfig informs the code generator that the API follows a
@@ -141,8 +233,85 @@ type FieldGeneratorConfig struct {
 	// that owns the resource. This is a special field that we direct to
 	// storage in the common `Status.ACKResourceMetadata.OwnerAccountID` field.
 	ContainsOwnerAccountID bool `json:"contains_owner_account_id"`
+	// Type overrides the Go type that the code generator would otherwise
+	// infer for this Attribute. Attributes unpacked from a raw
+	// `map[string]*string` are, absent this field, always typed as
+	// `*string`. Setting Type tells the generator to emit the real Go type
+	// for the Spec/Status field along with the parse/format helpers needed
+	// to convert to and from the underlying string value in the Attributes
+	// map.
+	//
+	// Supported values are "string", "int", "bool", "duration", "json" and
+	// "arn". Any Attribute key without a Type is left as `*string`, and any
+	// key present in the raw map that isn't listed in Fields at all is
+	// passed through to the Attributes map unchanged.
+	Type FieldType `json:"type,omitempty"`
+	// Default, if set, is the value the generated accessor returns when the
+	// Attribute key is absent from the raw map entirely.
+	Default *string `json:"default,omitempty"`
+	// Required indicates the Attribute must be present and non-empty. The
+	// generator surfaces Required fields as part of the CRD's OpenAPI
+	// validation schema so that missing values are rejected by the API
+	// server at admission time instead of failing later as an opaque AWS
+	// error during reconciliation.
+	Required bool `json:"required,omitempty"`
+	// Validation contains OpenAPI validation constraints -- length, range,
+	// pattern, enum, format -- that the generator translates into
+	// `+kubebuilder:validation:*` markers on the emitted Go struct field, so
+	// that an invalid Spec is rejected at admission time instead of
+	// surfacing as an opaque AWS `ValidationException` during reconcile.
+	Validation *ValidationConfig `json:"validation,omitempty"`
+	// Immutable indicates the field cannot be changed after the resource is
+	// created. The generator emits a CEL `x-kubernetes-validations` rule
+	// forbidding updates to the field -- useful for fields like SQS's
+	// `FifoQueue` that AWS itself refuses to let you modify.
+	Immutable bool `json:"immutable,omitempty"`
 }
 
+// ValidationConfig contains OpenAPI validation constraints that the code
+// generator translates into `+kubebuilder:validation:*` markers on a CRD's
+// Spec or Status field.
+type ValidationConfig struct {
+	// MinLength is the minimum length of a string field.
+	MinLength *int64 `json:"min_length,omitempty"`
+	// MaxLength is the maximum length of a string field.
+	MaxLength *int64 `json:"max_length,omitempty"`
+	// Pattern is a regular expression a string field's value must match.
+	Pattern string `json:"pattern,omitempty"`
+	// Minimum is the minimum value of a numeric field.
+	Minimum *float64 `json:"minimum,omitempty"`
+	// Maximum is the maximum value of a numeric field.
+	Maximum *float64 `json:"maximum,omitempty"`
+	// Enum lists the only values a field's value may take.
+	Enum []string `json:"enum,omitempty"`
+	// Format is the OpenAPI `format` of the field, e.g. `date-time` or
+	// `byte`.
+	Format string `json:"format,omitempty"`
+}
+
+// FieldType identifies the real Go type that an Attribute unpacked from a
+// raw `map[string]*string` should be presented as on a CRD's Spec or Status
+// struct.
+type FieldType string
+
+const (
+	// FieldTypeString leaves the Attribute as a `*string`. This is the
+	// default behaviour when Type is not set.
+	FieldTypeString FieldType = "string"
+	// FieldTypeInt unpacks the Attribute into an `*int64`.
+	FieldTypeInt FieldType = "int"
+	// FieldTypeBool unpacks the Attribute into a `*bool`.
+	FieldTypeBool FieldType = "bool"
+	// FieldTypeDuration unpacks the Attribute, a string of seconds, into a
+	// `*metav1.Duration`.
+	FieldTypeDuration FieldType = "duration"
+	// FieldTypeJSON unpacks the Attribute, a JSON-encoded string, into a
+	// `map[string]interface{}`.
+	FieldTypeJSON FieldType = "json"
+	// FieldTypeARN unpacks the Attribute into an `*ackv1alpha1.AWSResourceName`.
+	FieldTypeARN FieldType = "arn"
+)
+
 // ExceptionsConfig contains instructions to the code generator about how to
 // handle the exceptions for the operations on a resource. These instructions
 // are necessary for those APIs where the API models do not contain any
@@ -153,6 +322,23 @@ type ExceptionsConfig struct {
 	// Codes is a map of HTTP status code to the name of the Exception shape
 	// that corresponds to that HTTP status code for this resource
 	Codes map[int]string `json:"codes"`
+	// NotFound is the `awserr.Error.Code()` string that signals the primary
+	// resource doesn't exist, e.g. "InvalidSubnetID.NotFound" for EC2. Many
+	// AWS APIs -- EC2 and the newer restjson services in particular --
+	// return a single HTTP status (usually 400) for a large number of
+	// distinct error codes, so the HTTP status alone can't tell "resource
+	// not found" apart from "invalid parameter". When set, sdkFind uses
+	// this error code string to detect a missing resource instead of the
+	// HTTP status recorded in Codes.
+	NotFound string `json:"not_found,omitempty"`
+	// Terminal is the list of `awserr.Error.Code()` strings that indicate
+	// the reconciliation loop should stop retrying and mark the resource as
+	// being in a terminal, unrecoverable condition.
+	Terminal []string `json:"terminal,omitempty"`
+	// Retriable is the list of `awserr.Error.Code()` strings that indicate
+	// the reconciliation loop should requeue and try again, e.g. because
+	// the error is transient or the resource is still being provisioned.
+	Retriable []string `json:"retriable,omitempty"`
 }
 
 // RenamesConfig contains instructions to the code generator how to rename
@@ -181,18 +367,484 @@ type ListOperationConfig struct {
 	MatchFields []string `json:"match_fields"`
 }
 
+// IdentifierConfig contains instructions to the code generator about how to
+// resolve and manage a resource's primary identifier. This exists because
+// NameField's hardcoded "Name"/"{Resource}Name"/"{Resource}Id" fallback
+// chain only handles the simplest case; many AWS APIs identify a resource
+// by ARN alone, by a composite of several fields, or expect the stability
+// of a user-supplied name (e.g. an S3 bucket) to be treated as part of its
+// identity rather than just another Spec field.
+type IdentifierConfig struct {
+	// PrimaryField is the name of the Member of the Create Input shape (or,
+	// for ComposedOf identifiers, of one of its component fields) that acts
+	// as the resource's identifier.
+	PrimaryField string `json:"primary_field,omitempty"`
+	// IsARN indicates PrimaryField holds the resource's full ARN rather
+	// than a bare name or ID.
+	IsARN bool `json:"is_arn,omitempty"`
+	// ComposedOf lists the names of fields whose values, concatenated in
+	// order, form the resource's identity. Used for resources -- some RDS
+	// and Route53 types, for example -- that AWS doesn't expose a single
+	// identifier field for.
+	ComposedOf []string `json:"composed_of,omitempty"`
+	// Immutable indicates the identifier, once set, cannot be changed by
+	// updating the CR. The generator emits admission webhook validation
+	// that rejects any update attempting to change it.
+	Immutable bool `json:"immutable,omitempty"`
+	// ExternalNameAnnotation is the annotation key that, when present on
+	// the CR, lets a Kubernetes user adopt an existing AWS resource by its
+	// identifier instead of having the controller create a new one. The
+	// generator emits the corresponding lookup in sdkFind so that, on first
+	// reconcile, the controller looks up the AWS resource named by the
+	// annotation rather than calling Create.
+	ExternalNameAnnotation string `json:"external_name_annotation,omitempty"`
+}
+
+// sourceDocument is one YAML file (the root config, an include, or a
+// resource fragment) that contributed to a GeneratorConfig, kept around so
+// that Validate can re-check it for unknown fields without re-reading the
+// filesystem.
+type sourceDocument struct {
+	path               string
+	contents           []byte
+	isResourceFragment bool
+}
+
+// overrideTier identifies which stage of the merge a resourceOverride came
+// from. Tiers have a fixed, documented precedence -- root < include <
+// resource fragment -- so a higher-tier source is *expected* to override a
+// lower-tier one and that's never a conflict. Two sources in the *same*
+// tier (e.g. two includes) disagreeing about a resource is the case
+// conflictingOverride actually needs to catch.
+type overrideTier int
+
+const (
+	overrideTierRoot overrideTier = iota
+	overrideTierInclude
+	overrideTierResourceFragment
+)
+
+// resourceOverride records that a named resource's ResourceGeneratorConfig
+// was set, in whole or in part, by a particular source document (the root
+// config, an include, or a resource fragment). NewGeneratorConfig records
+// one of these per source per resource name so that Validate can detect two
+// same-tier sources setting the same field to different values.
+type resourceOverride struct {
+	source string
+	tier   overrideTier
+	config ResourceGeneratorConfig
+}
+
 // NewGeneratorConfig returns a new GeneratorConfig object given a supplied
-// path to a config file
+// path to a config file. The config file may be a single, self-contained
+// YAML document, or it may reference other files via its top-level
+// `includes:` list and/or contain a `resources/` directory, alongside
+// configPath, of per-resource YAML fragments named `<ResourceName>.yaml`.
+//
+// Includes are merged in list order, field by field: a later include only
+// overrides the specific fields it sets on a given resource, it does not
+// wipe out fields an earlier include or the root config already set on that
+// same resource. A resource fragment file is merged the same way, and wins
+// over anything set in configPath's own `resources:` block or an include.
 func NewGeneratorConfig(
 	configPath string,
 ) (*GeneratorConfig, error) {
-	gc := GeneratorConfig{}
-	contents, err := ioutil.ReadFile(configPath)
+	gc, contents, err := readGeneratorConfigFile(configPath)
 	if err != nil {
 		return nil, err
 	}
-	if err = yaml.Unmarshal(contents, &gc); err != nil {
+	gc.recordSource(configPath, contents, false)
+	gc.recordResourceOverrides(configPath, overrideTierRoot, gc.Resources)
+
+	baseDir := filepath.Dir(configPath)
+	for _, include := range gc.Includes {
+		includePath := include
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(baseDir, includePath)
+		}
+		included, includeContents, err := readGeneratorConfigFile(includePath)
+		if err != nil {
+			return nil, err
+		}
+		gc.recordSource(includePath, includeContents, false)
+		gc.recordResourceOverrides(includePath, overrideTierInclude, included.Resources)
+		gc.merge(included)
+	}
+	if err := gc.mergeResourceFragments(baseDir); err != nil {
 		return nil, err
 	}
-	return &gc, nil
+	gc.applyDefaults()
+	return gc, nil
+}
+
+// readGeneratorConfigFile reads and unmarshals a single generator config
+// YAML file, without resolving its includes or resource fragments, and
+// returns its raw contents alongside the parsed config.
+func readGeneratorConfigFile(path string) (*GeneratorConfig, []byte, error) {
+	gc := &GeneratorConfig{}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := yaml.Unmarshal(contents, gc); err != nil {
+		return nil, nil, err
+	}
+	return gc, contents, nil
+}
+
+// recordSource appends a YAML document to gc.sourceDocuments for later
+// unknown-field checking by Validate.
+func (gc *GeneratorConfig) recordSource(path string, contents []byte, isResourceFragment bool) {
+	gc.sourceDocuments = append(gc.sourceDocuments, sourceDocument{
+		path:               path,
+		contents:           contents,
+		isResourceFragment: isResourceFragment,
+	})
+}
+
+// recordResourceOverrides notes, for each named resource in resources, that
+// source (at the given precedence tier) set it, for later
+// conflicting-override detection by Validate.
+func (gc *GeneratorConfig) recordResourceOverrides(source string, tier overrideTier, resources map[string]ResourceGeneratorConfig) {
+	if len(resources) == 0 {
+		return
+	}
+	if gc.resourceOverrides == nil {
+		gc.resourceOverrides = map[string][]resourceOverride{}
+	}
+	for name, rc := range resources {
+		gc.resourceOverrides[name] = append(gc.resourceOverrides[name], resourceOverride{
+			source: source,
+			tier:   tier,
+			config: rc,
+		})
+	}
+}
+
+// merge overlays other on top of gc. Resources are merged field by field,
+// following the includes-override-earlier precedence rule described on
+// NewGeneratorConfig. The Ignore lists of other are appended to gc's. A
+// Kubernetes or Defaults block set in other replaces gc's block as a whole;
+// those two blocks are not merged field by field.
+func (gc *GeneratorConfig) merge(other *GeneratorConfig) {
+	if len(other.Resources) > 0 {
+		if gc.Resources == nil {
+			gc.Resources = map[string]ResourceGeneratorConfig{}
+		}
+		for name, rc := range other.Resources {
+			if existing, found := gc.Resources[name]; found {
+				gc.Resources[name] = mergeResourceGeneratorConfig(existing, rc)
+			} else {
+				gc.Resources[name] = rc
+			}
+		}
+	}
+	gc.Ignore.Operations = append(gc.Ignore.Operations, other.Ignore.Operations...)
+	gc.Ignore.ResourceNames = append(gc.Ignore.ResourceNames, other.Ignore.ResourceNames...)
+	gc.Ignore.ShapeNames = append(gc.Ignore.ShapeNames, other.Ignore.ShapeNames...)
+	if other.Kubernetes != nil {
+		gc.Kubernetes = other.Kubernetes
+	}
+	if other.Defaults != nil {
+		gc.Defaults = other.Defaults
+	}
+}
+
+// mergeResourceFragments discovers per-resource YAML fragments in a
+// `resources/` directory next to the root config file and merges them,
+// field by field, into gc.Resources, overriding any entry of the same name
+// already present from the root config's `resources:` block or an include.
+func (gc *GeneratorConfig) mergeResourceFragments(baseDir string) error {
+	fragmentsDir := filepath.Join(baseDir, "resources")
+	entries, err := ioutil.ReadDir(fragmentsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(entry.Name())
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		resourceName := strings.TrimSuffix(entry.Name(), ext)
+		fragmentPath := filepath.Join(fragmentsDir, entry.Name())
+		contents, err := ioutil.ReadFile(fragmentPath)
+		if err != nil {
+			return err
+		}
+		rc := ResourceGeneratorConfig{}
+		if err := yaml.Unmarshal(contents, &rc); err != nil {
+			return err
+		}
+		gc.recordSource(fragmentPath, contents, true)
+		gc.recordResourceOverrides(fragmentPath, overrideTierResourceFragment, map[string]ResourceGeneratorConfig{resourceName: rc})
+		if gc.Resources == nil {
+			gc.Resources = map[string]ResourceGeneratorConfig{}
+		}
+		if existing, found := gc.Resources[resourceName]; found {
+			gc.Resources[resourceName] = mergeResourceGeneratorConfig(existing, rc)
+		} else {
+			gc.Resources[resourceName] = rc
+		}
+	}
+	return nil
+}
+
+// applyDefaults fills in, on every resource, any field that Defaults sets
+// but the resource itself left unset, field by field and recursively into
+// any nested struct (Exceptions, Kubernetes, Identifier, ...). The
+// service-wide GeneratorConfig.Kubernetes default, if set, is folded in as
+// the base that Defaults.Kubernetes (and then the resource's own
+// Kubernetes) layer on top of.
+func (gc *GeneratorConfig) applyDefaults() {
+	if gc.Defaults == nil && gc.Kubernetes == nil {
+		return
+	}
+	defaults := ResourceGeneratorConfig{}
+	if gc.Defaults != nil {
+		defaults = *gc.Defaults
+	}
+	if gc.Kubernetes != nil {
+		merged := mergeReflectValue(reflect.ValueOf(gc.Kubernetes), reflect.ValueOf(defaults.Kubernetes))
+		defaults.Kubernetes, _ = merged.Interface().(*KubernetesConfig)
+	}
+	for name, rc := range gc.Resources {
+		gc.Resources[name] = mergeResourceGeneratorConfig(defaults, rc)
+	}
+}
+
+// mergeResourceGeneratorConfig returns a copy of base with every non-zero
+// field of override recursively merged on top of it -- see
+// mergeReflectValue for the precise, per-Kind merge rule.
+//
+// This is used both for field-level precedence between two sources (two
+// includes, or a resource fragment and what came before it) and for
+// defaulting (base is GeneratorConfig.Defaults, override is the resource's
+// own config).
+func mergeResourceGeneratorConfig(base, override ResourceGeneratorConfig) ResourceGeneratorConfig {
+	merged := mergeReflectValue(reflect.ValueOf(base), reflect.ValueOf(override))
+	return merged.Interface().(ResourceGeneratorConfig)
+}
+
+// mergeReflectValue recursively overlays override on top of base, so that a
+// source setting only `exceptions.retriable` doesn't wipe out another
+// source's `exceptions.codes` on the same resource. Handled by Kind:
+//
+//   - Ptr: if either side is nil, the other wins outright; if both are set,
+//     their pointed-to values are merged recursively.
+//   - Struct: merged field by field.
+//   - Map: merged key by key, recursing into any key both sides set so
+//     that, for example, two Fields entries for different Attribute keys
+//     both survive.
+//   - anything else (string, bool, number, slice): override wins wholesale
+//     when it isn't the zero value, otherwise base is kept. Slices are not
+//     merged element-by-element -- a ComposedOf or Categories list is
+//     replaced as a whole, not concatenated.
+func mergeReflectValue(base, override reflect.Value) reflect.Value {
+	switch override.Kind() {
+	case reflect.Ptr:
+		if override.IsNil() {
+			return base
+		}
+		if base.IsNil() {
+			return override
+		}
+		merged := reflect.New(override.Type().Elem())
+		merged.Elem().Set(mergeReflectValue(base.Elem(), override.Elem()))
+		return merged
+	case reflect.Struct:
+		merged := reflect.New(override.Type()).Elem()
+		for i := 0; i < override.NumField(); i++ {
+			merged.Field(i).Set(mergeReflectValue(base.Field(i), override.Field(i)))
+		}
+		return merged
+	case reflect.Map:
+		if override.IsNil() {
+			return base
+		}
+		if base.IsNil() {
+			return override
+		}
+		merged := reflect.MakeMap(override.Type())
+		for _, key := range base.MapKeys() {
+			merged.SetMapIndex(key, base.MapIndex(key))
+		}
+		for _, key := range override.MapKeys() {
+			ov := override.MapIndex(key)
+			if bv := base.MapIndex(key); bv.IsValid() {
+				merged.SetMapIndex(key, mergeReflectValue(bv, ov))
+			} else {
+				merged.SetMapIndex(key, ov)
+			}
+		}
+		return merged
+	default:
+		if override.IsZero() {
+			return base
+		}
+		return override
+	}
+}
+
+// conflictingOverride reports the first leaf field that two *same-tier*
+// sources (two includes, or two resource fragments) set to different
+// non-zero values for the same resource, e.g. two includes both setting
+// `exceptions.not_found` to a different error code. Returns "" if no two
+// same-tier sources disagree.
+//
+// Cross-tier disagreements -- a resource fragment overriding a field the
+// root config or an include set, or an include overriding the root config
+// -- are deliberately not flagged here: that's the documented, deterministic
+// precedence order (root < include < resource fragment), not a conflict.
+func conflictingOverride(overrides []resourceOverride) string {
+	byTier := map[overrideTier][]resourceOverride{}
+	for _, o := range overrides {
+		byTier[o.tier] = append(byTier[o.tier], o)
+	}
+	for _, tierOverrides := range byTier {
+		for i := 0; i < len(tierOverrides); i++ {
+			for j := i + 1; j < len(tierOverrides); j++ {
+				a, b := tierOverrides[i], tierOverrides[j]
+				conflict := findLeafConflict(reflect.ValueOf(a.config), reflect.ValueOf(b.config), "")
+				if conflict != "" {
+					return fmt.Sprintf(
+						"field %q set to different values by %s and %s",
+						conflict, a.source, b.source,
+					)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// findLeafConflict walks a and b -- two values of the same type -- in
+// parallel and returns the dotted path of the first leaf where both sides
+// are non-zero but disagree. A field only one side set is never a
+// conflict, even if nested under a struct or pointer both sides happen to
+// set -- e.g. one source's `exceptions.codes` and another's
+// `exceptions.retriable` don't conflict just because both set `exceptions`.
+func findLeafConflict(a, b reflect.Value, path string) string {
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return ""
+		}
+		return findLeafConflict(a.Elem(), b.Elem(), path)
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < a.NumField(); i++ {
+			fieldPath := t.Field(i).Name
+			if path != "" {
+				fieldPath = path + "." + fieldPath
+			}
+			if conflict := findLeafConflict(a.Field(i), b.Field(i), fieldPath); conflict != "" {
+				return conflict
+			}
+		}
+		return ""
+	case reflect.Map:
+		if a.IsNil() || b.IsNil() {
+			return ""
+		}
+		for _, key := range a.MapKeys() {
+			bv := b.MapIndex(key)
+			if !bv.IsValid() {
+				continue
+			}
+			if conflict := findLeafConflict(a.MapIndex(key), bv, fmt.Sprintf("%s[%v]", path, key)); conflict != "" {
+				return conflict
+			}
+		}
+		return ""
+	default:
+		if a.IsZero() || b.IsZero() {
+			return ""
+		}
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return path
+		}
+		return ""
+	}
+}
+
+// checkUnknownFields re-decodes a YAML source document against the shape of
+// out, returning an error if the document contains a field out doesn't
+// declare. yaml.Unmarshal silently ignores unknown fields, so this exists
+// purely to catch typos -- e.g. `exceptons:` instead of `exceptions:` --
+// that would otherwise fail silently.
+func checkUnknownFields(contents []byte, out interface{}) error {
+	jsonContents, err := yaml.YAMLToJSON(contents)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonContents))
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+// Validate checks the GeneratorConfig for common mistakes: unknown fields
+// in any of its source YAML documents, resource names that don't
+// correspond to any resource in the supplied API model, resources that are
+// simultaneously ignored and configured, and two same-tier sources (e.g.
+// two includes) setting the same resource field to different values.
+// knownResourceNames should list every resource name discoverable in the
+// service's API model; pass nil to skip that check.
+func (gc *GeneratorConfig) Validate(knownResourceNames []string) error {
+	var problems []string
+
+	for _, doc := range gc.sourceDocuments {
+		var err error
+		if doc.isResourceFragment {
+			err = checkUnknownFields(doc.contents, &ResourceGeneratorConfig{})
+		} else {
+			err = checkUnknownFields(doc.contents, &GeneratorConfig{})
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", doc.path, err))
+		}
+	}
+
+	if len(knownResourceNames) > 0 {
+		known := map[string]bool{}
+		for _, name := range knownResourceNames {
+			known[name] = true
+		}
+		for name := range gc.Resources {
+			if !known[name] {
+				problems = append(problems, fmt.Sprintf(
+					"resources: %q does not match any resource in the API model", name,
+				))
+			}
+		}
+	}
+
+	for _, name := range gc.Ignore.ResourceNames {
+		if _, found := gc.Resources[name]; found {
+			problems = append(problems, fmt.Sprintf(
+				"resources: %q is both ignored and configured", name,
+			))
+		}
+	}
+
+	for name, overrides := range gc.resourceOverrides {
+		if len(overrides) < 2 {
+			continue
+		}
+		if conflict := conflictingOverride(overrides); conflict != "" {
+			problems = append(problems, fmt.Sprintf(
+				"resources: %q has conflicting overrides: %s", name, conflict,
+			))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid generator config: %s", strings.Join(problems, "; "))
+	}
+	return nil
 }
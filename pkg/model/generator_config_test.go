@@ -0,0 +1,365 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFile writes contents to dir/name, creating any parent
+// directories that don't already exist.
+func writeTestFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll(%s): %v", filepath.Dir(path), err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("WriteFile(%s): %v", path, err)
+	}
+	return path
+}
+
+func TestNewGeneratorConfig_IncludeFieldLevelOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ack-generator-config")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, dir, "exceptions.yaml", `
+resources:
+  Topic:
+    exceptions:
+      not_found: NotFoundException
+`)
+	writeTestFile(t, dir, "kubernetes.yaml", `
+resources:
+  Topic:
+    kubernetes:
+      short_names: [topic]
+`)
+	rootPath := writeTestFile(t, dir, "generator.yaml", `
+includes:
+  - exceptions.yaml
+  - kubernetes.yaml
+`)
+
+	gc, err := NewGeneratorConfig(rootPath)
+	if err != nil {
+		t.Fatalf("NewGeneratorConfig: %v", err)
+	}
+
+	topic, found := gc.Resources["Topic"]
+	if !found {
+		t.Fatalf("expected a Topic resource, got %#v", gc.Resources)
+	}
+	if topic.Exceptions == nil || topic.Exceptions.NotFound != "NotFoundException" {
+		t.Errorf("expected Topic.Exceptions.NotFound from exceptions.yaml to survive, got %#v", topic.Exceptions)
+	}
+	if topic.Kubernetes == nil || len(topic.Kubernetes.ShortNames) != 1 || topic.Kubernetes.ShortNames[0] != "topic" {
+		t.Errorf("expected Topic.Kubernetes.ShortNames from kubernetes.yaml, got %#v", topic.Kubernetes)
+	}
+}
+
+func TestNewGeneratorConfig_ResourceFragmentOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ack-generator-config")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootPath := writeTestFile(t, dir, "generator.yaml", `
+resources:
+  Topic:
+    name_field: TopicName
+`)
+	writeTestFile(t, dir, "resources/Topic.yaml", `
+exceptions:
+  not_found: NotFoundException
+`)
+
+	gc, err := NewGeneratorConfig(rootPath)
+	if err != nil {
+		t.Fatalf("NewGeneratorConfig: %v", err)
+	}
+
+	topic, found := gc.Resources["Topic"]
+	if !found {
+		t.Fatalf("expected a Topic resource, got %#v", gc.Resources)
+	}
+	if topic.NameField == nil || *topic.NameField != "TopicName" {
+		t.Errorf("expected Topic.NameField from the root resources: block to survive, got %#v", topic.NameField)
+	}
+	if topic.Exceptions == nil || topic.Exceptions.NotFound != "NotFoundException" {
+		t.Errorf("expected Topic.Exceptions from the resource fragment to apply, got %#v", topic.Exceptions)
+	}
+}
+
+func TestNewGeneratorConfig_DisjointNestedFieldsDontClobberOrConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ack-generator-config")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, dir, "codes.yaml", `
+resources:
+  Topic:
+    exceptions:
+      codes:
+        404: NotFoundException
+`)
+	writeTestFile(t, dir, "retriable.yaml", `
+resources:
+  Topic:
+    exceptions:
+      retriable: [ThrottlingException]
+`)
+	rootPath := writeTestFile(t, dir, "generator.yaml", `
+includes:
+  - codes.yaml
+  - retriable.yaml
+`)
+
+	gc, err := NewGeneratorConfig(rootPath)
+	if err != nil {
+		t.Fatalf("NewGeneratorConfig: %v", err)
+	}
+
+	topic, found := gc.Resources["Topic"]
+	if !found {
+		t.Fatalf("expected a Topic resource, got %#v", gc.Resources)
+	}
+	if topic.Exceptions == nil || topic.Exceptions.Codes[404] != "NotFoundException" {
+		t.Errorf("expected Exceptions.Codes from codes.yaml to survive retriable.yaml's include, got %#v", topic.Exceptions)
+	}
+	if topic.Exceptions == nil || len(topic.Exceptions.Retriable) != 1 || topic.Exceptions.Retriable[0] != "ThrottlingException" {
+		t.Errorf("expected Exceptions.Retriable from retriable.yaml, got %#v", topic.Exceptions)
+	}
+
+	if err := gc.Validate(nil); err != nil {
+		t.Errorf("two includes setting disjoint Exceptions sub-fields should not be a conflict, got %v", err)
+	}
+}
+
+func TestNewGeneratorConfig_FragmentOverridingRootIsNotAConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ack-generator-config")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootPath := writeTestFile(t, dir, "generator.yaml", `
+resources:
+  Topic:
+    name_field: TopicName
+`)
+	writeTestFile(t, dir, "resources/Topic.yaml", `
+name_field: TopicARN
+`)
+
+	gc, err := NewGeneratorConfig(rootPath)
+	if err != nil {
+		t.Fatalf("NewGeneratorConfig: %v", err)
+	}
+
+	topic := gc.Resources["Topic"]
+	if topic.NameField == nil || *topic.NameField != "TopicARN" {
+		t.Errorf("expected the resource fragment's name_field to win, got %#v", topic.NameField)
+	}
+	if err := gc.Validate(nil); err != nil {
+		t.Errorf("a resource fragment overriding a root-level field is documented precedence, not a conflict, got %v", err)
+	}
+}
+
+func TestNewGeneratorConfig_ServiceWideKubernetesDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ack-generator-config")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootPath := writeTestFile(t, dir, "generator.yaml", `
+kubernetes:
+  categories: [aws, sns]
+resources:
+  Topic:
+    kubernetes:
+      short_names: [topic]
+  Subscription: {}
+`)
+
+	gc, err := NewGeneratorConfig(rootPath)
+	if err != nil {
+		t.Fatalf("NewGeneratorConfig: %v", err)
+	}
+
+	topic := gc.Resources["Topic"]
+	if topic.Kubernetes == nil || len(topic.Kubernetes.Categories) != 2 {
+		t.Errorf("expected Topic.Kubernetes.Categories to fall back to the service-wide default, got %#v", topic.Kubernetes)
+	}
+	if topic.Kubernetes == nil || len(topic.Kubernetes.ShortNames) != 1 || topic.Kubernetes.ShortNames[0] != "topic" {
+		t.Errorf("expected Topic's own ShortNames to survive alongside the defaulted Categories, got %#v", topic.Kubernetes)
+	}
+
+	subscription := gc.Resources["Subscription"]
+	if subscription.Kubernetes == nil || len(subscription.Kubernetes.Categories) != 2 {
+		t.Errorf("expected Subscription, which set no Kubernetes config of its own, to inherit the service-wide default, got %#v", subscription.Kubernetes)
+	}
+}
+
+func TestNewGeneratorConfig_ApplyDefaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ack-generator-config")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootPath := writeTestFile(t, dir, "generator.yaml", `
+defaults:
+  exceptions:
+    not_found: NotFoundException
+  kubernetes:
+    categories: [aws, sns]
+resources:
+  Topic:
+    kubernetes:
+      categories: [aws, sns, custom]
+`)
+
+	gc, err := NewGeneratorConfig(rootPath)
+	if err != nil {
+		t.Fatalf("NewGeneratorConfig: %v", err)
+	}
+
+	topic, found := gc.Resources["Topic"]
+	if !found {
+		t.Fatalf("expected a Topic resource, got %#v", gc.Resources)
+	}
+	if topic.Exceptions == nil || topic.Exceptions.NotFound != "NotFoundException" {
+		t.Errorf("expected Topic.Exceptions to be filled in from Defaults, got %#v", topic.Exceptions)
+	}
+	if topic.Kubernetes == nil || len(topic.Kubernetes.Categories) != 3 {
+		t.Errorf("expected Topic's own Kubernetes to win over Defaults, got %#v", topic.Kubernetes)
+	}
+}
+
+func TestGeneratorConfig_Validate(t *testing.T) {
+	t.Run("unknown resource name", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "ack-generator-config")
+		if err != nil {
+			t.Fatalf("TempDir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		rootPath := writeTestFile(t, dir, "generator.yaml", `
+resources:
+  Tpoic:
+    name_field: TopicName
+`)
+		gc, err := NewGeneratorConfig(rootPath)
+		if err != nil {
+			t.Fatalf("NewGeneratorConfig: %v", err)
+		}
+		err = gc.Validate([]string{"Topic", "Subscription"})
+		if err == nil || !strings.Contains(err.Error(), `"Tpoic" does not match any resource`) {
+			t.Errorf("expected an unknown-resource-name error, got %v", err)
+		}
+	})
+
+	t.Run("ignored and configured", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "ack-generator-config")
+		if err != nil {
+			t.Fatalf("TempDir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		rootPath := writeTestFile(t, dir, "generator.yaml", `
+ignore:
+  resource_names: [Topic]
+resources:
+  Topic:
+    name_field: TopicName
+`)
+		gc, err := NewGeneratorConfig(rootPath)
+		if err != nil {
+			t.Fatalf("NewGeneratorConfig: %v", err)
+		}
+		err = gc.Validate(nil)
+		if err == nil || !strings.Contains(err.Error(), `"Topic" is both ignored and configured`) {
+			t.Errorf("expected an ignored-and-configured error, got %v", err)
+		}
+	})
+
+	t.Run("conflicting include overrides", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "ack-generator-config")
+		if err != nil {
+			t.Fatalf("TempDir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		writeTestFile(t, dir, "a.yaml", `
+resources:
+  Topic:
+    exceptions:
+      not_found: NotFoundException
+`)
+		writeTestFile(t, dir, "b.yaml", `
+resources:
+  Topic:
+    exceptions:
+      not_found: TopicNotFoundException
+`)
+		rootPath := writeTestFile(t, dir, "generator.yaml", `
+includes:
+  - a.yaml
+  - b.yaml
+`)
+		gc, err := NewGeneratorConfig(rootPath)
+		if err != nil {
+			t.Fatalf("NewGeneratorConfig: %v", err)
+		}
+		err = gc.Validate(nil)
+		if err == nil || !strings.Contains(err.Error(), "conflicting overrides") {
+			t.Errorf("expected a conflicting-overrides error, got %v", err)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "ack-generator-config")
+		if err != nil {
+			t.Fatalf("TempDir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		rootPath := writeTestFile(t, dir, "generator.yaml", `
+resorces:
+  Topic:
+    name_field: TopicName
+`)
+		gc, err := NewGeneratorConfig(rootPath)
+		if err != nil {
+			t.Fatalf("NewGeneratorConfig: %v", err)
+		}
+		err = gc.Validate(nil)
+		if err == nil || !strings.Contains(err.Error(), "unknown field") {
+			t.Errorf("expected an unknown-field error, got %v", err)
+		}
+	})
+}